@@ -0,0 +1,55 @@
+package conf
+
+const (
+	// config file
+	ConfigFilename = "config.json"
+
+	// for monitoring
+	DefaultMonitorIntervalSeconds = 1
+	ScheduleCheckIntervalSeconds  = 60
+
+	// variables
+	MinImageWidth  = 640
+	MinImageHeight = 480
+
+	// defaults for motion detection, used when not set in config
+	DefaultMotionThreshold       = 30.0
+	DefaultMotionCooldownSeconds = 60
+
+	// dimensions of the low-resolution preview frames used for motion detection
+	MotionPreviewWidth  = 64
+	MotionPreviewHeight = 48
+
+	// webhook
+	DefaultWebhookListenAddr = ":8443"
+	ShutdownTimeoutSeconds   = 10
+
+	// metrics and rate limiting
+	DefaultMetricsListenAddr = ":9090"
+	DefaultRatePerMinute     = 10.0
+	DefaultBurstSize         = 5
+
+	// camera backend, probed automatically in preference order when "auto"
+	DefaultCameraBackend = "auto"
+
+	// commands
+	CommandStart   = "/start"
+	CommandCapture = "/capture"
+	CommandRecord  = "/record"
+	CommandStatus  = "/status"
+	CommandHelp    = "/help"
+	CommandCancel  = "/cancel"
+
+	CommandSchedule   = "/schedule"
+	CommandSchedules  = "/schedules"
+	CommandPause      = "/pause"
+	CommandResume     = "/resume"
+	CommandUnschedule = "/unschedule"
+
+	// messages
+	MessageDefault        = "Input your command:"
+	MessageUnknownCommand = "Unknown command."
+
+	// default maintenance message
+	DefaultMaintenanceMessage = "Service is in maintenance now."
+)
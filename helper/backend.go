@@ -0,0 +1,163 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// interface for the different command-line tools capable of capturing a
+// still image, so the bot can run on hosts where raspistill isn't available
+type CameraBackend interface {
+	Capture(ctx context.Context, width, height int, params map[string]interface{}) ([]byte, error)
+	Name() string
+	Probe() error
+}
+
+// known backends, most Pi-specific first
+var backendsInPreferenceOrder = []CameraBackend{
+	raspistillBackend{},
+	libcameraStillBackend{},
+	fswebcamBackend{},
+	gphoto2Backend{},
+}
+
+// resolve a backend by name; empty name or "auto" probes each known backend
+// in preference order and returns the first one that's usable
+func SelectCameraBackend(name string) (CameraBackend, error) {
+	if len(name) == 0 || name == "auto" {
+		for _, backend := range backendsInPreferenceOrder {
+			if err := backend.Probe(); err == nil {
+				return backend, nil
+			}
+		}
+
+		return nil, fmt.Errorf("no usable camera backend found")
+	}
+
+	for _, backend := range backendsInPreferenceOrder {
+		if backend.Name() == name {
+			if err := backend.Probe(); err != nil {
+				return nil, fmt.Errorf("camera backend '%s' failed probe: %s", name, err)
+			}
+
+			return backend, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown camera backend: %s", name)
+}
+
+// run cmd, which is expected to write a JPEG to output, then read it back
+func captureToFile(cmd *exec.Cmd, output string) ([]byte, error) {
+	defer os.Remove(output)
+
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(output)
+}
+
+// captures stills with raspistill (Raspbian / Buster and earlier)
+type raspistillBackend struct{}
+
+func (raspistillBackend) Name() string { return "raspistill" }
+
+func (raspistillBackend) Probe() error {
+	_, err := exec.LookPath("raspistill")
+	return err
+}
+
+func (raspistillBackend) Capture(ctx context.Context, width, height int, params map[string]interface{}) ([]byte, error) {
+	output := fmt.Sprintf("/tmp/%d-raspistill.jpg", os.Getpid())
+
+	args := []string{
+		"-w", fmt.Sprintf("%d", width),
+		"-h", fmt.Sprintf("%d", height),
+		"-o", output,
+	}
+	for k, v := range params {
+		args = append(args, fmt.Sprintf("-%s", k), fmt.Sprintf("%v", v))
+	}
+
+	return captureToFile(exec.CommandContext(ctx, "raspistill", args...), output)
+}
+
+// captures stills with libcamera-still (Bullseye / Bookworm)
+type libcameraStillBackend struct{}
+
+func (libcameraStillBackend) Name() string { return "libcamera-still" }
+
+func (libcameraStillBackend) Probe() error {
+	_, err := exec.LookPath("libcamera-still")
+	return err
+}
+
+func (libcameraStillBackend) Capture(ctx context.Context, width, height int, params map[string]interface{}) ([]byte, error) {
+	output := fmt.Sprintf("/tmp/%d-libcamera-still.jpg", os.Getpid())
+
+	args := []string{
+		"--width", fmt.Sprintf("%d", width),
+		"--height", fmt.Sprintf("%d", height),
+		"--timeout", "1",
+		"--output", output,
+	}
+	for k, v := range params {
+		args = append(args, fmt.Sprintf("--%s", k), fmt.Sprintf("%v", v))
+	}
+
+	return captureToFile(exec.CommandContext(ctx, "libcamera-still", args...), output)
+}
+
+// captures stills from a USB webcam with fswebcam
+type fswebcamBackend struct{}
+
+func (fswebcamBackend) Name() string { return "fswebcam" }
+
+func (fswebcamBackend) Probe() error {
+	_, err := exec.LookPath("fswebcam")
+	return err
+}
+
+func (fswebcamBackend) Capture(ctx context.Context, width, height int, params map[string]interface{}) ([]byte, error) {
+	output := fmt.Sprintf("/tmp/%d-fswebcam.jpg", os.Getpid())
+
+	args := []string{
+		"-r", fmt.Sprintf("%dx%d", width, height),
+		"--no-banner",
+		output,
+	}
+	for k, v := range params {
+		args = append(args, fmt.Sprintf("--%s", k), fmt.Sprintf("%v", v))
+	}
+
+	return captureToFile(exec.CommandContext(ctx, "fswebcam", args...), output)
+}
+
+// captures stills by tethering a DSLR/mirrorless camera with gphoto2
+type gphoto2Backend struct{}
+
+func (gphoto2Backend) Name() string { return "gphoto2" }
+
+func (gphoto2Backend) Probe() error {
+	_, err := exec.LookPath("gphoto2")
+	return err
+}
+
+func (gphoto2Backend) Capture(ctx context.Context, width, height int, params map[string]interface{}) ([]byte, error) {
+	// gphoto2 captures at the camera's native resolution; width/height unused
+	output := fmt.Sprintf("/tmp/%d-gphoto2.jpg", os.Getpid())
+
+	args := []string{
+		"--capture-image-and-download",
+		"--filename", output,
+		"--force-overwrite",
+	}
+	for k, v := range params {
+		args = append(args, fmt.Sprintf("--%s", k), fmt.Sprintf("%v", v))
+	}
+
+	return captureToFile(exec.CommandContext(ctx, "gphoto2", args...), output)
+}
@@ -0,0 +1,39 @@
+package helper
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// record a video clip of the given duration (in seconds) with raspivid and
+// return the resulting MP4 bytes
+func CaptureRaspiVid(duration, width, height int, params map[string]interface{}) (result []byte, err error) {
+	output := fmt.Sprintf("/tmp/%d.h264", os.Getpid())
+	mp4Output := fmt.Sprintf("/tmp/%d.mp4", os.Getpid())
+	defer os.Remove(output)
+	defer os.Remove(mp4Output)
+
+	args := []string{
+		"-t", fmt.Sprintf("%d", duration*1000),
+		"-w", fmt.Sprintf("%d", width),
+		"-h", fmt.Sprintf("%d", height),
+		"-o", output,
+	}
+	for k, v := range params {
+		args = append(args, fmt.Sprintf("-%s", k), fmt.Sprintf("%v", v))
+	}
+
+	cmd := exec.Command("raspivid", args...)
+	if err = cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	// mux the raw h264 stream into an MP4 container so it can be sent as a video
+	muxCmd := exec.Command("MP4Box", "-add", output, mp4Output)
+	if err = muxCmd.Run(); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(mp4Output)
+}
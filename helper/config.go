@@ -0,0 +1,49 @@
+package helper
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/meinside/telegram-bot-rpi-camera/conf"
+)
+
+// struct for config file
+type Config struct {
+	ApiToken           string                 `json:"api_token"`
+	AvailableIds       []string               `json:"available_ids"`
+	MonitorInterval    int                    `json:"monitor_interval"`
+	ImageWidth         int                    `json:"image_width"`
+	ImageHeight        int                    `json:"image_height"`
+	CameraParams       map[string]interface{} `json:"camera_params"`
+	IsInMaintenance    bool                   `json:"is_in_maintenance"`
+	MaintenanceMessage string                 `json:"maintenance_message"`
+	LogglyToken        string                 `json:"loggly_token,omitempty"`
+	IsVerbose          bool                   `json:"is_verbose"`
+
+	MotionDetectionEnabled bool     `json:"motion_detection_enabled,omitempty"`
+	MotionThreshold        float64  `json:"motion_threshold,omitempty"`
+	MotionCooldownSeconds  int      `json:"motion_cooldown_seconds,omitempty"`
+	MotionSubscribers      []string `json:"motion_subscribers,omitempty"`
+
+	WebhookURL         string `json:"webhook_url,omitempty"`
+	WebhookListenAddr  string `json:"webhook_listen_addr,omitempty"`
+	WebhookCertFile    string `json:"webhook_cert_file,omitempty"`
+	WebhookKeyFile     string `json:"webhook_key_file,omitempty"`
+	WebhookSecretToken string `json:"webhook_secret_token,omitempty"`
+
+	MetricsListenAddr string  `json:"metrics_listen_addr,omitempty"`
+	RatePerMinute     float64 `json:"rate_per_minute,omitempty"`
+	BurstSize         int     `json:"burst_size,omitempty"`
+
+	CameraBackend string `json:"camera_backend,omitempty"`
+}
+
+// read config
+func GetConfig() (config Config, err error) {
+	var bytes []byte
+	if bytes, err = os.ReadFile(conf.ConfigFilename); err == nil {
+		err = json.Unmarshal(bytes, &config)
+	}
+
+	return config, err
+}
@@ -0,0 +1,232 @@
+package helper
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	dbFilename = "./bot.db"
+)
+
+type Database struct {
+	db *sql.DB
+}
+
+type Photo struct {
+	Username string
+	FileId   string
+	Caption  string
+}
+
+type Video struct {
+	Username string
+	FileId   string
+	Caption  string
+}
+
+// a recurring capture job registered by a user
+type Schedule struct {
+	ID               int64
+	Username         string
+	Rule             string
+	CameraParamsJSON string
+	NextRun          time.Time
+	Enabled          bool
+}
+
+// open (creating if needed) this bot's local SQLite database
+func OpenDb() *Database {
+	db, err := sql.Open("sqlite3", dbFilename)
+	if err != nil {
+		panic("Failed to open database: " + err.Error())
+	}
+
+	for _, stmt := range []string{
+		`create table if not exists photos (
+			username text,
+			file_id text,
+			caption text,
+			captured_at datetime default current_timestamp
+		)`,
+		`create table if not exists videos (
+			username text,
+			file_id text,
+			caption text,
+			captured_at datetime default current_timestamp
+		)`,
+		`create table if not exists schedules (
+			id integer primary key autoincrement,
+			username text,
+			rule text,
+			camera_params_json text,
+			next_run datetime,
+			enabled boolean default 1
+		)`,
+		`create table if not exists chat_ids (
+			username text primary key,
+			chat_id integer
+		)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			panic("Failed to initialize database: " + err.Error())
+		}
+	}
+
+	return &Database{db: db}
+}
+
+// save a captured photo's cached file id for later retrieval via inline query
+func (d *Database) SavePhoto(username, fileId, caption string) {
+	if _, err := d.db.Exec(`insert into photos (username, file_id, caption) values (?, ?, ?)`, username, fileId, caption); err != nil {
+		log.Printf("*** Failed to save photo: %s\n", err.Error())
+	}
+}
+
+// retrieve the latest `limit` cached photos for the given user, newest first
+func (d *Database) GetPhotos(username string, limit int) (photos []Photo) {
+	rows, err := d.db.Query(`select username, file_id, caption from photos where username = ? order by captured_at desc limit ?`, username, limit)
+	if err != nil {
+		log.Printf("*** Failed to retrieve photos: %s\n", err.Error())
+		return photos
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p Photo
+		if err := rows.Scan(&p.Username, &p.FileId, &p.Caption); err == nil {
+			photos = append(photos, p)
+		}
+	}
+
+	return photos
+}
+
+// save a captured video's cached file id for later retrieval via inline query
+func (d *Database) SaveVideo(username, fileId, caption string) {
+	if _, err := d.db.Exec(`insert into videos (username, file_id, caption) values (?, ?, ?)`, username, fileId, caption); err != nil {
+		log.Printf("*** Failed to save video: %s\n", err.Error())
+	}
+}
+
+// retrieve the latest `limit` cached videos for the given user, newest first
+func (d *Database) GetVideos(username string, limit int) (videos []Video) {
+	rows, err := d.db.Query(`select username, file_id, caption from videos where username = ? order by captured_at desc limit ?`, username, limit)
+	if err != nil {
+		log.Printf("*** Failed to retrieve videos: %s\n", err.Error())
+		return videos
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v Video
+		if err := rows.Scan(&v.Username, &v.FileId, &v.Caption); err == nil {
+			videos = append(videos, v)
+		}
+	}
+
+	return videos
+}
+
+// register a new recurring capture job for the given user
+func (d *Database) AddSchedule(username, rule, cameraParamsJSON string, nextRun time.Time) (id int64, err error) {
+	var result sql.Result
+	if result, err = d.db.Exec(`insert into schedules (username, rule, camera_params_json, next_run, enabled) values (?, ?, ?, ?, 1)`,
+		username, rule, cameraParamsJSON, nextRun); err != nil {
+		log.Printf("*** Failed to add schedule: %s\n", err.Error())
+		return 0, err
+	}
+
+	return result.LastInsertId()
+}
+
+// retrieve all schedules registered by the given user
+func (d *Database) GetSchedules(username string) (schedules []Schedule) {
+	rows, err := d.db.Query(`select id, username, rule, camera_params_json, next_run, enabled from schedules where username = ? order by id`, username)
+	if err != nil {
+		log.Printf("*** Failed to retrieve schedules: %s\n", err.Error())
+		return schedules
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s Schedule
+		if err := rows.Scan(&s.ID, &s.Username, &s.Rule, &s.CameraParamsJSON, &s.NextRun, &s.Enabled); err == nil {
+			schedules = append(schedules, s)
+		}
+	}
+
+	return schedules
+}
+
+// retrieve all enabled schedules whose next run is at or before `now`
+func (d *Database) DueSchedules(now time.Time) (schedules []Schedule) {
+	rows, err := d.db.Query(`select id, username, rule, camera_params_json, next_run, enabled from schedules where enabled = 1 and next_run <= ?`, now)
+	if err != nil {
+		log.Printf("*** Failed to retrieve due schedules: %s\n", err.Error())
+		return schedules
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s Schedule
+		if err := rows.Scan(&s.ID, &s.Username, &s.Rule, &s.CameraParamsJSON, &s.NextRun, &s.Enabled); err == nil {
+			schedules = append(schedules, s)
+		}
+	}
+
+	return schedules
+}
+
+// update a schedule's next scheduled run time
+func (d *Database) SetScheduleNextRun(id int64, nextRun time.Time) {
+	if _, err := d.db.Exec(`update schedules set next_run = ? where id = ?`, nextRun, id); err != nil {
+		log.Printf("*** Failed to update schedule's next run: %s\n", err.Error())
+	}
+}
+
+// pause or resume a schedule owned by the given user
+func (d *Database) SetScheduleEnabled(id int64, username string, enabled bool) (ok bool) {
+	result, err := d.db.Exec(`update schedules set enabled = ? where id = ? and username = ?`, enabled, id, username)
+	if err != nil {
+		log.Printf("*** Failed to update schedule: %s\n", err.Error())
+		return false
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0
+}
+
+// remove a schedule owned by the given user
+func (d *Database) DeleteSchedule(id int64, username string) (ok bool) {
+	result, err := d.db.Exec(`delete from schedules where id = ? and username = ?`, id, username)
+	if err != nil {
+		log.Printf("*** Failed to delete schedule: %s\n", err.Error())
+		return false
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0
+}
+
+// remember the chat id a user's /start came from, so scheduled and other
+// bot-initiated jobs know where to deliver results
+func (d *Database) SaveChatID(username string, chatID int64) {
+	if _, err := d.db.Exec(`insert into chat_ids (username, chat_id) values (?, ?) on conflict(username) do update set chat_id = excluded.chat_id`,
+		username, chatID); err != nil {
+		log.Printf("*** Failed to save chat id: %s\n", err.Error())
+	}
+}
+
+// retrieve the chat id last seen for the given user
+func (d *Database) GetChatID(username string) (chatID int64, exists bool) {
+	row := d.db.QueryRow(`select chat_id from chat_ids where username = ?`, username)
+	if err := row.Scan(&chatID); err != nil {
+		return 0, false
+	}
+
+	return chatID, true
+}
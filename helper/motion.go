@@ -0,0 +1,57 @@
+package helper
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"image/jpeg"
+)
+
+// grab a single low-resolution preview frame for motion detection via the
+// selected camera backend, so motion detection works on whichever backend
+// was probed at startup rather than being tied to raspistill
+func CapturePreviewFrame(backend CameraBackend, width, height int) ([]byte, error) {
+	return backend.Capture(context.Background(), width, height, nil)
+}
+
+// decode a JPEG preview frame and downsample it (nearest neighbor) into a
+// flat slice of grayscale luminance values, sized width*height
+func GrayscaleFrame(jpegBytes []byte, width, height int) ([]float64, error) {
+	img, err := jpeg.Decode(bytes.NewReader(jpegBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+
+	gray := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		srcY := bounds.Min.Y + y*srcHeight/height
+		for x := 0; x < width; x++ {
+			srcX := bounds.Min.X + x*srcWidth/width
+			gray[y*width+x] = float64(color.GrayModel.Convert(img.At(srcX, srcY)).(color.Gray).Y)
+		}
+	}
+
+	return gray, nil
+}
+
+// mean absolute per-pixel difference between two equally-sized grayscale
+// frames, as produced by GrayscaleFrame
+func MeanAbsDiff(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var sum float64
+	for i := range a {
+		diff := a[i] - b[i]
+		if diff < 0 {
+			diff = -diff
+		}
+		sum += diff
+	}
+
+	return sum / float64(len(a))
+}
@@ -0,0 +1,25 @@
+package helper
+
+import "testing"
+
+func TestMeanAbsDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []float64
+		want float64
+	}{
+		{"identical frames", []float64{10, 20, 30}, []float64{10, 20, 30}, 0},
+		{"uniform difference", []float64{0, 0, 0, 0}, []float64{10, 10, 10, 10}, 10},
+		{"mixed difference", []float64{0, 10}, []float64{10, 0}, 10},
+		{"empty frames", []float64{}, []float64{}, 0},
+		{"mismatched lengths", []float64{1, 2, 3}, []float64{1, 2}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MeanAbsDiff(tt.a, tt.b); got != tt.want {
+				t.Errorf("MeanAbsDiff(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
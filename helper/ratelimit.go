@@ -0,0 +1,57 @@
+package helper
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// available tokens for a single rate-limited key
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// per-key token-bucket rate limiter
+type RateLimiter struct {
+	mu            sync.Mutex
+	buckets       map[string]*tokenBucket
+	ratePerSecond float64
+	burst         float64
+}
+
+// allow ratePerMinute requests per minute per key, with bursts of up to
+// `burst` requests
+func NewRateLimiter(ratePerMinute float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		buckets:       map[string]*tokenBucket{},
+		ratePerSecond: ratePerMinute / 60.0,
+		burst:         float64(burst),
+	}
+}
+
+// report whether a request for the given key is allowed to proceed right
+// now, consuming a token if so
+func (r *RateLimiter) Allow(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+
+	bucket, exists := r.buckets[key]
+	if !exists {
+		bucket = &tokenBucket{tokens: r.burst, lastRefill: now}
+		r.buckets[key] = bucket
+	}
+
+	elapsed := now.Sub(bucket.lastRefill).Seconds()
+	bucket.tokens = math.Min(r.burst, bucket.tokens+elapsed*r.ratePerSecond)
+	bucket.lastRefill = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+
+	bucket.tokens--
+	return true
+}
@@ -0,0 +1,31 @@
+package helper
+
+import "testing"
+
+func TestRateLimiterAllowsUpToBurst(t *testing.T) {
+	limiter := NewRateLimiter(60, 3)
+
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow("user") {
+			t.Fatalf("expected request %d within burst to be allowed", i)
+		}
+	}
+
+	if limiter.Allow("user") {
+		t.Fatal("expected request beyond burst to be throttled")
+	}
+}
+
+func TestRateLimiterTracksKeysIndependently(t *testing.T) {
+	limiter := NewRateLimiter(60, 1)
+
+	if !limiter.Allow("alice") {
+		t.Fatal("expected first request for alice to be allowed")
+	}
+	if !limiter.Allow("bob") {
+		t.Fatal("expected first request for bob to be allowed, independent of alice's bucket")
+	}
+	if limiter.Allow("alice") {
+		t.Fatal("expected second immediate request for alice to be throttled")
+	}
+}
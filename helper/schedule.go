@@ -0,0 +1,198 @@
+package helper
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parse a schedule rule and return the next time (strictly after `after`)
+// at which it should fire
+//
+// two rule formats are supported:
+//
+//	every <duration> [<HH:MM>-<HH:MM>]   eg. "every 10m 08:00-20:00"
+//	cron <5-field crontab expression>    eg. `cron "0 */2 * * *"`
+func ComputeNextRun(rule string, after time.Time) (next time.Time, err error) {
+	rule = strings.TrimSpace(rule)
+
+	switch {
+	case strings.HasPrefix(rule, "every "):
+		return nextIntervalRun(strings.TrimSpace(strings.TrimPrefix(rule, "every ")), after)
+	case strings.HasPrefix(rule, "cron "):
+		expr := strings.Trim(strings.TrimSpace(strings.TrimPrefix(rule, "cron ")), `"`)
+		return nextCronRun(expr, after)
+	}
+
+	return next, fmt.Errorf("unrecognized schedule rule: %s", rule)
+}
+
+// handles the `every <duration> [<window>]` rule format
+func nextIntervalRun(rest string, after time.Time) (next time.Time, err error) {
+	parts := strings.Fields(rest)
+	if len(parts) == 0 {
+		return next, fmt.Errorf("missing interval in schedule rule")
+	}
+
+	interval, err := time.ParseDuration(parts[0])
+	if err != nil {
+		return next, fmt.Errorf("invalid interval '%s': %s", parts[0], err)
+	}
+
+	next = after.Add(interval)
+
+	// optional HH:MM-HH:MM active window
+	if len(parts) > 1 {
+		var windowStart, windowEnd int
+		if windowStart, windowEnd, err = parseWindow(parts[1]); err != nil {
+			return next, err
+		}
+
+		minutes := next.Hour()*60 + next.Minute()
+		if minutes < windowStart {
+			next = time.Date(next.Year(), next.Month(), next.Day(), windowStart/60, windowStart%60, 0, 0, next.Location())
+		} else if minutes > windowEnd {
+			next = time.Date(next.Year(), next.Month(), next.Day(), windowStart/60, windowStart%60, 0, 0, next.Location()).AddDate(0, 0, 1)
+		}
+	}
+
+	return next, nil
+}
+
+// parse a "HH:MM-HH:MM" string into minutes-since-midnight bounds
+func parseWindow(window string) (start, end int, err error) {
+	bounds := strings.SplitN(window, "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("invalid time window: %s", window)
+	}
+
+	if start, err = parseHHMM(bounds[0]); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parseHHMM(bounds[1]); err != nil {
+		return 0, 0, err
+	}
+
+	return start, end, nil
+}
+
+func parseHHMM(value string) (minutes int, err error) {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time '%s', expected HH:MM", value)
+	}
+
+	hour, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid hour in '%s'", value)
+	}
+	min, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid minute in '%s'", value)
+	}
+
+	return hour*60 + min, nil
+}
+
+// a parsed crontab field: either "*" (any), a fixed set of allowed
+// values, or a step (every N units starting from the field's minimum)
+type cronField struct {
+	any  bool
+	step int
+	min  int
+	set  map[int]bool
+}
+
+func parseCronField(field string, min, max int) (cronField, error) {
+	if field == "*" {
+		return cronField{any: true}, nil
+	}
+
+	if strings.HasPrefix(field, "*/") {
+		step, err := strconv.Atoi(strings.TrimPrefix(field, "*/"))
+		if err != nil || step <= 0 {
+			return cronField{}, fmt.Errorf("invalid step field: %s", field)
+		}
+		return cronField{step: step, min: min}, nil
+	}
+
+	set := map[int]bool{}
+	for _, part := range strings.Split(field, ",") {
+		value, err := strconv.Atoi(part)
+		if err != nil || value < min || value > max {
+			return cronField{}, fmt.Errorf("invalid field value: %s", part)
+		}
+		set[value] = true
+	}
+
+	return cronField{set: set}, nil
+}
+
+func (f cronField) matches(value int) bool {
+	if f.any {
+		return true
+	}
+	if f.step > 0 {
+		return (value-f.min)%f.step == 0
+	}
+	return f.set[value]
+}
+
+// a parsed 5-field crontab expression: minute hour day-of-month month day-of-week
+type cronSchedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek cronField
+}
+
+func parseCronExpr(expr string) (cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return cronSchedule{}, fmt.Errorf("expected 5 cron fields, got %d: %s", len(fields), expr)
+	}
+
+	var sched cronSchedule
+	var err error
+	if sched.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return sched, err
+	}
+	if sched.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return sched, err
+	}
+	if sched.dayOfMonth, err = parseCronField(fields[2], 1, 31); err != nil {
+		return sched, err
+	}
+	if sched.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return sched, err
+	}
+	if sched.dayOfWeek, err = parseCronField(fields[4], 0, 6); err != nil {
+		return sched, err
+	}
+
+	return sched, nil
+}
+
+func (s cronSchedule) matches(t time.Time) bool {
+	return s.minute.matches(t.Minute()) &&
+		s.hour.matches(t.Hour()) &&
+		s.dayOfMonth.matches(t.Day()) &&
+		s.month.matches(int(t.Month())) &&
+		s.dayOfWeek.matches(int(t.Weekday()))
+}
+
+// brute-force, minute by minute, the next time a cron expression fires after `after`
+func nextCronRun(expr string, after time.Time) (next time.Time, err error) {
+	sched, err := parseCronExpr(expr)
+	if err != nil {
+		return next, err
+	}
+
+	next = after.Truncate(time.Minute).Add(time.Minute)
+	for i := 0; i < 366*24*60; i++ {
+		if sched.matches(next) {
+			return next, nil
+		}
+		next = next.Add(time.Minute)
+	}
+
+	return next, fmt.Errorf("no upcoming run found for cron expression: %s", expr)
+}
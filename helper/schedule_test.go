@@ -0,0 +1,57 @@
+package helper
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeNextRunCronStepAnchorsToFieldMin(t *testing.T) {
+	// day-of-month has min=1, so "every 5 days" should land on 1, 6, 11, ...
+	// rather than 0, 5, 10 as a naive `value % step == 0` check would produce
+	after := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	next, err := ComputeNextRun(`cron "0 0 */5 * *"`, after)
+	if err != nil {
+		t.Fatalf("ComputeNextRun returned error: %s", err)
+	}
+
+	want := time.Date(2026, time.January, 6, 0, 0, 0, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Errorf("next run = %s, want %s", next, want)
+	}
+}
+
+func TestComputeNextRunCronStepOnZeroMinField(t *testing.T) {
+	// minute/hour have min=0, so the step check is unaffected by the anchor fix
+	after := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	next, err := ComputeNextRun(`cron "0 */2 * * *"`, after)
+	if err != nil {
+		t.Fatalf("ComputeNextRun returned error: %s", err)
+	}
+
+	want := time.Date(2026, time.January, 1, 2, 0, 0, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Errorf("next run = %s, want %s", next, want)
+	}
+}
+
+func TestComputeNextRunEveryInterval(t *testing.T) {
+	after := time.Date(2026, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	next, err := ComputeNextRun("every 10m", after)
+	if err != nil {
+		t.Fatalf("ComputeNextRun returned error: %s", err)
+	}
+
+	want := after.Add(10 * time.Minute)
+	if !next.Equal(want) {
+		t.Errorf("next run = %s, want %s", next, want)
+	}
+}
+
+func TestComputeNextRunUnrecognizedRule(t *testing.T) {
+	if _, err := ComputeNextRun("whenever", time.Now()); err == nil {
+		t.Error("expected error for unrecognized schedule rule")
+	}
+}
@@ -2,16 +2,24 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	bot "github.com/meinside/telegram-bot-go"
 
 	"github.com/meinside/telegram-bot-rpi-camera/conf"
 	"github.com/meinside/telegram-bot-rpi-camera/helper"
+	"github.com/meinside/telegram-bot-rpi-camera/metrics"
 
 	"github.com/meinside/loggly-go"
 )
@@ -52,6 +60,17 @@ type _captureRequest struct {
 	MessageOptions map[string]interface{}
 }
 
+// record (video) request
+type _recordRequest struct {
+	UserName       string
+	ChatID         interface{}
+	Duration       int
+	ImageWidth     int
+	ImageHeight    int
+	CameraParams   map[string]interface{}
+	MessageOptions map[string]interface{}
+}
+
 // variables
 var apiToken string
 var monitorInterval int
@@ -61,16 +80,35 @@ var imageWidth, imageHeight int
 var cameraParams map[string]interface{}
 var isInMaintenance bool
 var maintenanceMessage string
+var motionDetectionEnabled bool
+var motionThreshold float64
+var motionCooldownSeconds int
+var motionSubscribers []string
+var webhookURL string
+var webhookListenAddr string
+var webhookCertFile string
+var webhookKeyFile string
+var webhookSecretToken string
+var metricsListenAddr string
+var rateLimiter *helper.RateLimiter
+var cameraBackend helper.CameraBackend
 var pool _sessionPool
 var captureChannel chan _captureRequest
+var recordChannel chan _recordRequest
 var launched time.Time
 var logger *loggly.Loggly
 var db *helper.Database
 
 const (
 	appName = "RPiCameraBot"
+
+	// prefix for callback data carrying a chosen recording duration (seconds)
+	callbackRecordDurationPrefix = "record_duration:"
 )
 
+// available recording durations (in seconds) offered for the /record command
+var recordDurations = []int{5, 15, 30}
+
 type logglyLog struct {
 	Application string      `json:"app"`
 	Severity    string      `json:"severity"`
@@ -81,7 +119,7 @@ type logglyLog struct {
 
 // keyboards
 var allKeyboards = [][]bot.KeyboardButton{
-	bot.NewKeyboardButtons(conf.CommandCapture),
+	bot.NewKeyboardButtons(conf.CommandCapture, conf.CommandRecord),
 	bot.NewKeyboardButtons(conf.CommandStatus, conf.CommandHelp),
 }
 var cancelKeyboard = [][]bot.KeyboardButton{
@@ -122,6 +160,55 @@ func init() {
 			maintenanceMessage = conf.DefaultMaintenanceMessage
 		}
 
+		// motion detection
+		motionDetectionEnabled = config.MotionDetectionEnabled
+		motionThreshold = config.MotionThreshold
+		if motionThreshold <= 0 {
+			motionThreshold = conf.DefaultMotionThreshold
+		}
+		motionCooldownSeconds = config.MotionCooldownSeconds
+		if motionCooldownSeconds <= 0 {
+			motionCooldownSeconds = conf.DefaultMotionCooldownSeconds
+		}
+		motionSubscribers = config.MotionSubscribers
+
+		// webhook transport
+		webhookURL = config.WebhookURL
+		webhookListenAddr = config.WebhookListenAddr
+		if len(webhookListenAddr) <= 0 {
+			webhookListenAddr = conf.DefaultWebhookListenAddr
+		}
+		webhookCertFile = config.WebhookCertFile
+		webhookKeyFile = config.WebhookKeyFile
+		webhookSecretToken = config.WebhookSecretToken
+
+		// metrics and rate limiting
+		metricsListenAddr = config.MetricsListenAddr
+		if len(metricsListenAddr) <= 0 {
+			metricsListenAddr = conf.DefaultMetricsListenAddr
+		}
+		ratePerMinute := config.RatePerMinute
+		if ratePerMinute <= 0 {
+			ratePerMinute = conf.DefaultRatePerMinute
+		}
+		burstSize := config.BurstSize
+		if burstSize <= 0 {
+			burstSize = conf.DefaultBurstSize
+		}
+		rateLimiter = helper.NewRateLimiter(ratePerMinute, burstSize)
+
+		// camera backend
+		backendName := config.CameraBackend
+		if len(backendName) <= 0 {
+			backendName = conf.DefaultCameraBackend
+		}
+		if backend, err := helper.SelectCameraBackend(backendName); err == nil {
+			cameraBackend = backend
+			logMessage(fmt.Sprintf("Using camera backend: %s", backend.Name()))
+		} else {
+			panic(fmt.Sprintf("Failed to select camera backend: %s", err))
+		}
+
 		// initialize session variables
 		sessions := make(map[string]_session)
 		for _, v := range availableIds {
@@ -137,6 +224,7 @@ func init() {
 
 		// channels
 		captureChannel = make(chan _captureRequest, numQueue)
+		recordChannel = make(chan _recordRequest, numQueue)
 
 		// loggly
 		if config.LogglyToken != "" {
@@ -170,6 +258,15 @@ Following commands are supported:
 *For Raspberry Pi Camera Module*
 
 %s : capture a still image with *raspistill*
+%s : record a short video clip with *raspivid*
+
+*Scheduling*
+
+%s <rule> : register a recurring capture, eg. "every 10m 08:00-20:00" or cron "0 */2 * * *"
+%s : list your registered schedules
+%s <id> : pause a schedule
+%s <id> : resume a schedule
+%s <id> : remove a schedule
 
 *Others*
 
@@ -179,6 +276,12 @@ Following commands are supported:
 https://github.com/meinside/telegram-bot-rpi-camera
 `,
 		conf.CommandCapture,
+		conf.CommandRecord,
+		conf.CommandSchedule,
+		conf.CommandSchedules,
+		conf.CommandPause,
+		conf.CommandResume,
+		conf.CommandUnschedule,
 		conf.CommandStatus,
 		conf.CommandHelp,
 	)
@@ -189,6 +292,190 @@ func getStatus() string {
 	return fmt.Sprintf("Uptime: %s\nMemory Usage: %s", helper.GetUptime(launched), helper.GetMemoryUsage())
 }
 
+// register a new recurring capture job from a `/schedule <rule>` command,
+// eg. `/schedule every 10m 08:00-20:00`
+func addSchedule(userID, txt string) string {
+	rule := strings.TrimSpace(strings.TrimPrefix(txt, conf.CommandSchedule))
+	if len(rule) == 0 {
+		return "Usage: /schedule every <duration> [<HH:MM>-<HH:MM>]\nor: /schedule cron \"<5-field cron expression>\""
+	}
+
+	nextRun, err := helper.ComputeNextRun(rule, time.Now())
+	if err != nil {
+		return fmt.Sprintf("Invalid schedule rule: %s", err)
+	}
+
+	paramsJSON, err := json.Marshal(cameraParams)
+	if err != nil {
+		return fmt.Sprintf("Failed to save schedule: %s", err)
+	}
+
+	id, err := db.AddSchedule(userID, rule, string(paramsJSON), nextRun)
+	if err != nil {
+		return fmt.Sprintf("Failed to save schedule: %s", err)
+	}
+
+	return fmt.Sprintf("Scheduled #%d: %s\nNext run: %s", id, rule, nextRun.Format("2006-01-02 (Mon) 15:04:05"))
+}
+
+// format the calling user's registered schedules for a `/schedules` command
+func listSchedules(userID string) string {
+	schedules := db.GetSchedules(userID)
+	if len(schedules) == 0 {
+		return "No schedules registered."
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Registered schedules:\n")
+	for _, s := range schedules {
+		state := "enabled"
+		if !s.Enabled {
+			state = "paused"
+		}
+		sb.WriteString(fmt.Sprintf("#%d (%s): %s — next: %s\n", s.ID, state, s.Rule, s.NextRun.Format("2006-01-02 15:04:05")))
+	}
+
+	return sb.String()
+}
+
+// pause or resume a schedule from a `/pause <id>` or `/resume <id>` command
+func setScheduleEnabled(userID, txt, cmdPrefix string, enabled bool) string {
+	id, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(txt, cmdPrefix)), 10, 64)
+	if err != nil {
+		return fmt.Sprintf("Usage: %s <id>", cmdPrefix)
+	}
+
+	if db.SetScheduleEnabled(id, userID, enabled) {
+		if enabled {
+			return fmt.Sprintf("Schedule #%d resumed.", id)
+		}
+		return fmt.Sprintf("Schedule #%d paused.", id)
+	}
+
+	return fmt.Sprintf("No such schedule: #%d", id)
+}
+
+// remove a schedule from an `/unschedule <id>` command
+func unschedule(userID, txt string) string {
+	id, err := strconv.ParseInt(strings.TrimSpace(strings.TrimPrefix(txt, conf.CommandUnschedule)), 10, 64)
+	if err != nil {
+		return fmt.Sprintf("Usage: %s <id>", conf.CommandUnschedule)
+	}
+
+	if db.DeleteSchedule(id, userID) {
+		return fmt.Sprintf("Schedule #%d removed.", id)
+	}
+
+	return fmt.Sprintf("No such schedule: #%d", id)
+}
+
+// continuously grab low-res preview frames and, when the difference against
+// the previous frame exceeds the configured threshold, push a full-resolution
+// capture request for every subscriber; stops once ctx is done
+func monitorMotion(ctx context.Context) {
+	var previousFrame []float64
+	var lastDetectedAt time.Time
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		cameraLock.Lock()
+		jpegBytes, err := helper.CapturePreviewFrame(cameraBackend, conf.MotionPreviewWidth, conf.MotionPreviewHeight)
+		cameraLock.Unlock()
+		if err != nil {
+			logError(fmt.Sprintf("Failed to capture preview frame for motion detection: %s", err))
+			continue
+		}
+
+		frame, err := helper.GrayscaleFrame(jpegBytes, conf.MotionPreviewWidth, conf.MotionPreviewHeight)
+		if err != nil {
+			logError(fmt.Sprintf("Failed to decode preview frame: %s", err))
+			continue
+		}
+
+		if previousFrame != nil {
+			score := helper.MeanAbsDiff(previousFrame, frame)
+			cooldown := time.Duration(motionCooldownSeconds) * time.Second
+
+			if score > motionThreshold && time.Since(lastDetectedAt) > cooldown {
+				lastDetectedAt = time.Now()
+
+				logMessage(fmt.Sprintf("Motion detected (score=%.2f, threshold=%.2f)", score, motionThreshold))
+
+				if isInMaintenance {
+					logMessage("Not notifying motion subscribers: bot is in maintenance")
+					previousFrame = frame
+					continue
+				}
+
+				for _, subscriber := range motionSubscribers {
+					chatID, exists := db.GetChatID(subscriber)
+					if !exists {
+						logError(fmt.Sprintf("No chat id known for motion subscriber: %s (send /start first)", subscriber))
+						continue
+					}
+
+					captureChannel <- _captureRequest{
+						UserName:     subscriber,
+						ChatID:       chatID,
+						ImageWidth:   imageWidth,
+						ImageHeight:  imageHeight,
+						CameraParams: cameraParams,
+						MessageOptions: map[string]interface{}{
+							"caption":    fmt.Sprintf("Motion detected (score=%.2f)", score),
+							"parse_mode": bot.ParseModeMarkdown,
+						},
+					}
+				}
+			}
+		}
+
+		previousFrame = frame
+	}
+}
+
+// enqueue a capture request for every schedule that is due, and advance each
+// one to its next run time
+func runDueSchedules() {
+	for _, s := range db.DueSchedules(time.Now()) {
+		chatID, exists := db.GetChatID(s.Username)
+		if !exists {
+			logError(fmt.Sprintf("No chat id known for scheduled user: %s (send /start first)", s.Username))
+		} else if isInMaintenance {
+			logMessage(fmt.Sprintf("Skipping schedule #%d: bot is in maintenance", s.ID))
+		} else {
+			var params map[string]interface{}
+			if err := json.Unmarshal([]byte(s.CameraParamsJSON), &params); err != nil {
+				params = cameraParams
+			}
+
+			captureChannel <- _captureRequest{
+				UserName:     s.Username,
+				ChatID:       chatID,
+				ImageWidth:   imageWidth,
+				ImageHeight:  imageHeight,
+				CameraParams: params,
+				MessageOptions: map[string]interface{}{
+					"parse_mode": bot.ParseModeMarkdown,
+				},
+			}
+		}
+
+		if nextRun, err := helper.ComputeNextRun(s.Rule, time.Now()); err == nil {
+			db.SetScheduleNextRun(s.ID, nextRun)
+		} else {
+			logError(fmt.Sprintf("Failed to compute next run for schedule #%d: %s", s.ID, err))
+		}
+	}
+}
+
 // process incoming update from Telegram
 func processUpdate(b *bot.Bot, update bot.Update) bool {
 	// check username
@@ -203,6 +490,16 @@ func processUpdate(b *bot.Bot, update bot.Update) bool {
 		return false
 	}
 
+	// throttle users sending requests too quickly
+	if !rateLimiter.Allow(userID) {
+		metrics.ThrottledTotal.WithLabelValues(userID).Inc()
+
+		b.SendMessage(update.Message.Chat.ID, "Too many requests, please slow down.", nil)
+
+		return false
+	}
+	metrics.RequestsTotal.WithLabelValues(userID).Inc()
+
 	// process result
 	result := false
 
@@ -242,10 +539,37 @@ func processUpdate(b *bot.Bot, update bot.Update) bool {
 				case strings.HasPrefix(txt, conf.CommandStart):
 					message = conf.MessageDefault
 					cmd = conf.CommandStart
+
+					// remember this chat id so scheduled jobs know where to deliver results
+					db.SaveChatID(userID, update.Message.Chat.ID)
 				// capture
 				case strings.HasPrefix(txt, conf.CommandCapture):
 					message = ""
 					cmd = conf.CommandCapture
+				// record
+				case strings.HasPrefix(txt, conf.CommandRecord):
+					message = ""
+					cmd = conf.CommandRecord
+				// list schedules (checked before /schedule, which it would otherwise also match)
+				case strings.HasPrefix(txt, conf.CommandSchedules):
+					message = listSchedules(userID)
+					cmd = conf.CommandSchedules
+				// register a new schedule
+				case strings.HasPrefix(txt, conf.CommandSchedule):
+					message = addSchedule(userID, txt)
+					cmd = conf.CommandSchedule
+				// pause a schedule
+				case strings.HasPrefix(txt, conf.CommandPause):
+					message = setScheduleEnabled(userID, txt, conf.CommandPause, false)
+					cmd = conf.CommandPause
+				// resume a schedule
+				case strings.HasPrefix(txt, conf.CommandResume):
+					message = setScheduleEnabled(userID, txt, conf.CommandResume, true)
+					cmd = conf.CommandResume
+				// remove a schedule
+				case strings.HasPrefix(txt, conf.CommandUnschedule):
+					message = unschedule(userID, txt)
+					cmd = conf.CommandUnschedule
 				// status
 				case strings.HasPrefix(txt, conf.CommandStatus):
 					message = getStatus()
@@ -268,7 +592,21 @@ func processUpdate(b *bot.Bot, update bot.Update) bool {
 			// log request
 			logRequest(userID, cmd)
 
-			if len(message) > 0 {
+			if cmd == conf.CommandRecord {
+				if isInMaintenance {
+					if sent := b.SendMessage(update.Message.Chat.ID, maintenanceMessage, options); sent.Ok {
+						result = true
+					} else {
+						logError(fmt.Sprintf("Failed to send maintenance message: %s", *sent.Description))
+					}
+				} else if sent := b.SendMessage(update.Message.Chat.ID, "Choose a duration:", map[string]interface{}{
+					"reply_markup": recordDurationKeyboard(),
+				}); sent.Ok {
+					result = true
+				} else {
+					logError(fmt.Sprintf("Failed to send duration keyboard: %s", *sent.Description))
+				}
+			} else if len(message) > 0 {
 				// 'typing...'
 				b.SendChatAction(update.Message.Chat.ID, bot.ChatActionTyping)
 
@@ -321,7 +659,9 @@ func processCaptureRequest(b *bot.Bot, request _captureRequest) bool {
 	b.SendChatAction(request.ChatID, bot.ChatActionTyping)
 
 	// send photo
-	if bytes, err := helper.CaptureRaspiStill(request.ImageWidth, request.ImageHeight, request.CameraParams); err == nil {
+	if bytes, err := metrics.ObserveCapture(func() ([]byte, error) {
+		return cameraBackend.Capture(context.Background(), request.ImageWidth, request.ImageHeight, request.CameraParams)
+	}); err == nil {
 		// captured time
 		caption := time.Now().Format("2006-01-02 (Mon) 15:04:05")
 		request.MessageOptions["caption"] = caption
@@ -350,6 +690,126 @@ func processCaptureRequest(b *bot.Bot, request _captureRequest) bool {
 	return result
 }
 
+// process video record request
+func processRecordRequest(b *bot.Bot, request _recordRequest) bool {
+	// process result
+	result := false
+
+	cameraLock.Lock()
+	defer cameraLock.Unlock()
+
+	// 'typing...'
+	b.SendChatAction(request.ChatID, bot.ChatActionTyping)
+
+	// record video
+	if bytes, err := helper.CaptureRaspiVid(request.Duration, request.ImageWidth, request.ImageHeight, request.CameraParams); err == nil {
+		// captured time
+		caption := time.Now().Format("2006-01-02 (Mon) 15:04:05")
+		request.MessageOptions["caption"] = caption
+
+		// 'uploading video...'
+		b.SendChatAction(request.ChatID, bot.ChatActionUploadVideo)
+
+		// send video
+		if sent := b.SendVideo(request.ChatID, bot.InputFileFromBytes(bytes), request.MessageOptions); sent.Ok {
+			video := sent.Result.Video
+
+			db.SaveVideo(request.UserName, video.FileID, caption)
+
+			result = true
+		} else {
+			logError(fmt.Sprintf("Failed to send video: %s", *sent.Description))
+		}
+	} else {
+		message := fmt.Sprintf("Video capture failed: %s", err)
+
+		logError(message)
+
+		b.SendMessage(request.ChatID, message, request.MessageOptions)
+	}
+
+	return result
+}
+
+// build the inline keyboard offering the available recording durations
+func recordDurationKeyboard() bot.InlineKeyboardMarkup {
+	buttons := make([]bot.InlineKeyboardButton, len(recordDurations))
+	for i, seconds := range recordDurations {
+		data := fmt.Sprintf("%s%d", callbackRecordDurationPrefix, seconds)
+		buttons[i] = bot.InlineKeyboardButton{
+			Text:         fmt.Sprintf("%ds", seconds),
+			CallbackData: &data,
+		}
+	}
+
+	return bot.InlineKeyboardMarkup{
+		InlineKeyboard: [][]bot.InlineKeyboardButton{buttons},
+	}
+}
+
+// process incoming callback query (eg. duration chosen for /record)
+func processCallbackQuery(b *bot.Bot, update bot.Update) bool {
+	query := update.CallbackQuery
+
+	// check username
+	var userID string
+	if query.From.Username == nil {
+		logError(fmt.Sprintf("Callback Query - user not allowed (has no username): %s", query.From.FirstName))
+		return false
+	}
+	userID = *query.From.Username
+	if !isAvailableID(userID) {
+		logError(fmt.Sprintf("Callback Query - id not allowed: %s", userID))
+		return false
+	}
+
+	if query.Data == nil || !strings.HasPrefix(*query.Data, callbackRecordDurationPrefix) {
+		return false
+	}
+
+	// throttle users sending requests too quickly
+	if !rateLimiter.Allow(userID) {
+		metrics.ThrottledTotal.WithLabelValues(userID).Inc()
+
+		b.AnswerCallbackQuery(query.ID, map[string]interface{}{})
+		b.SendMessage(query.Message.Chat.ID, "Too many requests, please slow down.", nil)
+
+		return false
+	}
+	metrics.RequestsTotal.WithLabelValues(userID).Inc()
+
+	var duration int
+	if _, err := fmt.Sscanf(*query.Data, callbackRecordDurationPrefix+"%d", &duration); err != nil {
+		logError(fmt.Sprintf("Failed to parse record duration from callback data: %s", *query.Data))
+		return false
+	}
+
+	chatID := query.Message.Chat.ID
+
+	// acknowledge the callback query
+	b.AnswerCallbackQuery(query.ID, map[string]interface{}{})
+
+	if isInMaintenance {
+		b.SendMessage(chatID, maintenanceMessage, nil)
+		return true
+	}
+
+	// push to record request channel
+	recordChannel <- _recordRequest{
+		UserName:     userID,
+		ChatID:       chatID,
+		Duration:     duration,
+		ImageWidth:   imageWidth,
+		ImageHeight:  imageHeight,
+		CameraParams: cameraParams,
+		MessageOptions: map[string]interface{}{
+			"parse_mode": bot.ParseModeMarkdown,
+		},
+	}
+
+	return true
+}
+
 // process inline query
 func processInlineQuery(b *bot.Bot, update bot.Update) bool {
 	// check username
@@ -364,11 +824,12 @@ func processInlineQuery(b *bot.Bot, update bot.Update) bool {
 		return false
 	}
 
-	// retrieve cached photos,
+	// retrieve cached photos and videos,
 	photos := db.GetPhotos(userID, numLatestPhotos)
+	videos := db.GetVideos(userID, numLatestPhotos)
 
-	if len(photos) > 0 {
-		photoResults := []interface{}{}
+	if len(photos) > 0 || len(videos) > 0 {
+		results := []interface{}{}
 
 		// build up inline query results with cached photos,
 		for _, photo := range photos {
@@ -377,14 +838,25 @@ func processInlineQuery(b *bot.Bot, update bot.Update) bool {
 			if newPhoto, id := bot.NewInlineQueryResultCachedPhoto(photo.FileId); id != nil {
 				newPhoto.Caption = &caption
 
-				photoResults = append(photoResults, newPhoto)
+				results = append(results, newPhoto)
+			}
+		}
+
+		// and cached videos,
+		for _, video := range videos {
+			caption := video.Caption
+
+			if newVideo, id := bot.NewInlineQueryResultCachedVideo(caption, video.FileId); id != nil {
+				newVideo.Caption = &caption
+
+				results = append(results, newVideo)
 			}
 		}
 
 		// then answer inline query
 		sent := b.AnswerInlineQuery(
 			update.InlineQuery.ID,
-			photoResults,
+			results,
 			nil,
 		)
 
@@ -394,12 +866,24 @@ func processInlineQuery(b *bot.Bot, update bot.Update) bool {
 
 		logError(fmt.Sprintf("Failed to answer inline query: %s", *sent.Description))
 	} else {
-		logError("No cached photos for inline query.")
+		logError("No cached photos or videos for inline query.")
 	}
 
 	return false
 }
 
+// route a single incoming update to the right handler, shared between
+// long-polling and webhook transports
+func dispatchUpdate(b *bot.Bot, update bot.Update) {
+	if update.HasMessage() {
+		processUpdate(b, update)
+	} else if update.HasInlineQuery() {
+		processInlineQuery(b, update)
+	} else if update.HasCallbackQuery() {
+		processCallbackQuery(b, update)
+	}
+}
+
 func main() {
 	client := bot.NewClient(apiToken)
 	client.Verbose = isVerbose
@@ -408,34 +892,173 @@ func main() {
 	if me := client.GetMe(); me.Ok {
 		logMessage(fmt.Sprintf("Starting bot: @%s (%s)\n", *me.Result.Username, me.Result.FirstName))
 
-		// delete webhook (getting updates will not work when wehbook is set up)
-		if unhooked := client.DeleteWebhook(); unhooked.Ok {
-			// monitor request capture channel
-			go func() {
-				for {
-					select {
-					case request := <-captureChannel:
-						// do capture and send response
-						processCaptureRequest(client, request)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+		// serve Prometheus metrics at /metrics
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", metrics.Handler())
+
+			if err := http.ListenAndServe(metricsListenAddr, mux); err != nil {
+				logError(fmt.Sprintf("Metrics server error: %s", err))
+			}
+		}()
+
+		// keep the queue depth gauge up to date
+		go func() {
+			ticker := time.NewTicker(time.Second)
+			defer ticker.Stop()
+
+			for range ticker.C {
+				metrics.QueueDepth.Set(float64(len(captureChannel)))
+			}
+		}()
+
+		// tick the schedule checker; due schedules are read from the persistent
+		// database (opened in init()) on every tick, so they survive restarts
+		go func() {
+			ticker := time.NewTicker(conf.ScheduleCheckIntervalSeconds * time.Second)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					runDueSchedules()
+				}
+			}
+		}()
+
+		// monitor for motion, if enabled; stopped on shutdown so it can't
+		// push a fresh capture request after the drain loop has returned
+		if motionDetectionEnabled {
+			go monitorMotion(ctx)
+		}
+
+		// monitor request capture/record channels; on shutdown, drain
+		// whatever is left in the channels before returning
+		workerDone := make(chan struct{})
+		go func() {
+			defer close(workerDone)
+
+			for {
+				select {
+				case <-ctx.Done():
+					for {
+						select {
+						case request := <-captureChannel:
+							processCaptureRequest(client, request)
+						case request := <-recordChannel:
+							processRecordRequest(client, request)
+						default:
+							return
+						}
 					}
+				case request := <-captureChannel:
+					// do capture and send response
+					processCaptureRequest(client, request)
+				case request := <-recordChannel:
+					// do recording and send response
+					processRecordRequest(client, request)
 				}
-			}()
-
-			// wait for new updates
-			client.StartMonitoringUpdates(0, monitorInterval, func(b *bot.Bot, update bot.Update, err error) {
-				if err == nil {
-					if update.HasMessage() {
-						processUpdate(b, update)
-					} else if update.HasInlineQuery() {
-						processInlineQuery(b, update)
+			}
+		}()
+
+		var server *http.Server
+
+		if len(webhookURL) > 0 {
+			// webhook mode: register the webhook URL with Telegram,
+			// then serve updates over HTTP(S) instead of long polling
+			params := map[string]interface{}{}
+			if len(webhookSecretToken) > 0 {
+				params["secret_token"] = webhookSecretToken
+			}
+			if len(webhookCertFile) > 0 {
+				params["certificate"] = bot.InputFileFromFilepath(webhookCertFile)
+			}
+
+			if hooked := client.SetWebhook(webhookURL, params); hooked.Ok {
+				mux := http.NewServeMux()
+				mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+					if len(webhookSecretToken) > 0 && r.Header.Get("X-Telegram-Bot-Api-Secret-Token") != webhookSecretToken {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
 					}
-				} else {
-					logError(fmt.Sprintf("Error while receiving update (%s)", err.Error()))
+
+					var update bot.Update
+					if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+						logError(fmt.Sprintf("Failed to decode webhook update: %s", err))
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+
+					dispatchUpdate(client, update)
+
+					w.WriteHeader(http.StatusOK)
+				})
+
+				server = &http.Server{
+					Addr:    webhookListenAddr,
+					Handler: mux,
 				}
-			})
+
+				go func() {
+					var err error
+					if len(webhookCertFile) > 0 && len(webhookKeyFile) > 0 {
+						err = server.ListenAndServeTLS(webhookCertFile, webhookKeyFile)
+					} else {
+						err = server.ListenAndServe()
+					}
+					if err != nil && err != http.ErrServerClosed {
+						logError(fmt.Sprintf("Webhook server error: %s", err))
+					}
+				}()
+			} else {
+				panic("Failed to set webhook")
+			}
 		} else {
-			panic("Failed to delete webhook")
+			// delete webhook (getting updates will not work when webhook is set up)
+			if unhooked := client.DeleteWebhook(); unhooked.Ok {
+				// wait for new updates
+				go client.StartMonitoringUpdates(0, monitorInterval, func(b *bot.Bot, update bot.Update, err error) {
+					if err == nil {
+						dispatchUpdate(b, update)
+					} else {
+						logError(fmt.Sprintf("Error while receiving update (%s)", err.Error()))
+					}
+				})
+			} else {
+				panic("Failed to delete webhook")
+			}
 		}
+
+		// wait for a shutdown signal, then shut down gracefully; stop the
+		// webhook server from accepting new updates before telling the
+		// worker to drain and return, so a last-second webhook POST can't
+		// land on captureChannel/recordChannel after the worker has stopped
+		// watching them
+		<-sigCh
+		logMessage("Shutting down...")
+
+		if server != nil {
+			shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), conf.ShutdownTimeoutSeconds*time.Second)
+			server.Shutdown(shutdownCtx)
+			shutdownCancel()
+		}
+
+		cancel()
+
+		select {
+		case <-workerDone:
+		case <-time.After(conf.ShutdownTimeoutSeconds * time.Second):
+		}
+
+		logMessage("Shutdown complete.")
 	} else {
 		panic("Failed to get info of the bot")
 	}
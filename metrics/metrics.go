@@ -0,0 +1,88 @@
+// Package metrics exposes this bot's operational metrics as Prometheus
+// collectors, served over HTTP for scraping.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// CaptureTotal counts successful image captures.
+	CaptureTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "rpi_camera",
+		Name:      "capture_total",
+		Help:      "Total number of successful image captures.",
+	})
+
+	// CaptureLatencySeconds observes how long each capture takes.
+	CaptureLatencySeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "rpi_camera",
+		Name:      "capture_latency_seconds",
+		Help:      "Latency of image captures, in seconds.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	// QueueDepth reports the current depth of the capture request queue.
+	QueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "rpi_camera",
+		Name:      "queue_depth",
+		Help:      "Current number of pending requests in the capture queue.",
+	})
+
+	// ErrorsTotal counts errors, labeled by type.
+	ErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "rpi_camera",
+		Name:      "errors_total",
+		Help:      "Total number of errors, labeled by type.",
+	}, []string{"type"})
+
+	// RequestsTotal counts processed requests, labeled by the requesting user.
+	RequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "rpi_camera",
+		Name:      "requests_total",
+		Help:      "Total number of requests processed, labeled by user.",
+	}, []string{"user"})
+
+	// ThrottledTotal counts requests rejected by the per-user rate limiter.
+	ThrottledTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "rpi_camera",
+		Name:      "throttled_total",
+		Help:      "Total number of requests rejected by rate limiting, labeled by user.",
+	}, []string{"user"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		CaptureTotal,
+		CaptureLatencySeconds,
+		QueueDepth,
+		ErrorsTotal,
+		RequestsTotal,
+		ThrottledTotal,
+	)
+}
+
+// Handler returns the HTTP handler that serves these metrics for scraping.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveCapture runs `capture`, recording its latency and success/error count.
+func ObserveCapture(capture func() ([]byte, error)) ([]byte, error) {
+	started := time.Now()
+
+	bytes, err := capture()
+
+	CaptureLatencySeconds.Observe(time.Since(started).Seconds())
+	if err == nil {
+		CaptureTotal.Inc()
+	} else {
+		ErrorsTotal.WithLabelValues("capture").Inc()
+	}
+
+	return bytes, err
+}